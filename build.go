@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) 2017 Daniel MÃ¼ller
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package torrentfile
+
+import (
+	"crypto/sha1"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// BuildFromFilePath walks root and builds a TorrentFile describing its
+// contents. If root is a directory, the resulting TorrentFile is in
+// multi-file mode with one File entry per regular file found, sorted by
+// path so the same directory always produces the same InfoHash. If root
+// is a single file, the TorrentFile is built in single-file mode. A
+// directory that holds exactly one file, at its top level, also encodes in
+// single-file mode, with that file's name as the torrent name, because
+// Encode picks the mode from Files alone.
+//
+// The piece hashes are computed by streaming the concatenated contents
+// of all files through GeneratePieces.
+func BuildFromFilePath(root string, pieceLength uint64) (TorrentFile, error) {
+	fi, err := os.Stat(root)
+	if err != nil {
+		return TorrentFile{}, err
+	}
+
+	t := TorrentFile{
+		Name:        filepath.Base(root),
+		PieceLength: pieceLength,
+	}
+
+	var paths []string // absolute paths, in the order they will be hashed
+
+	if fi.IsDir() {
+		err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+
+			t.Files = append(t.Files, File{Length: uint64(info.Size()), Path: filepath.ToSlash(rel)})
+			return nil
+		})
+		if err != nil {
+			return TorrentFile{}, err
+		}
+
+		sort.Slice(t.Files, func(i, j int) bool { return t.Files[i].Path < t.Files[j].Path })
+
+		paths = make([]string, len(t.Files))
+		for i, f := range t.Files {
+			paths[i] = filepath.Join(root, filepath.FromSlash(f.Path))
+		}
+	} else {
+		t.Files = []File{{Length: uint64(fi.Size()), Path: t.Name}}
+		paths = []string{root}
+	}
+
+	if err := t.GeneratePieces(&sequentialFileReader{paths: paths}); err != nil {
+		return TorrentFile{}, err
+	}
+
+	return t, nil
+}
+
+// GeneratePieces reads r to completion, replacing t.Pieces with the SHA-1
+// digest of each PieceLength-sized chunk. The final, possibly short, chunk
+// is hashed as-is.
+func (t *TorrentFile) GeneratePieces(r io.Reader) error {
+	if t.PieceLength == 0 {
+		return errors.New("torrentfile: PieceLength must be greater than zero")
+	}
+
+	t.Pieces = t.Pieces[:0]
+	buf := make([]byte, t.PieceLength)
+
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			t.Pieces = append(t.Pieces, sha1.Sum(buf[:n]))
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sequentialFileReader concatenates the files at paths, like io.MultiReader
+// would, but opens each one lazily as the previous one is exhausted instead
+// of all of them up front. That keeps a multi-file torrent build from
+// holding every file open at once, which can exceed the process's file
+// descriptor limit for torrents with thousands of files.
+type sequentialFileReader struct {
+	paths []string
+	cur   *os.File
+}
+
+func (r *sequentialFileReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			if len(r.paths) == 0 {
+				return 0, io.EOF
+			}
+
+			f, err := os.Open(r.paths[0])
+			if err != nil {
+				return 0, err
+			}
+
+			r.cur = f
+			r.paths = r.paths[1:]
+		}
+
+		n, err := r.cur.Read(p)
+		if err == io.EOF {
+			r.cur.Close()
+			r.cur = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		if err != nil {
+			r.cur.Close()
+			r.cur = nil
+			return n, err
+		}
+
+		return n, nil
+	}
+}
@@ -0,0 +1,38 @@
+package torrentfile
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"testing"
+)
+
+func TestGeneratePiecesShortFinalPiece(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 25)
+
+	tf := TorrentFile{PieceLength: 10}
+	if err := tf.GeneratePieces(bytes.NewReader(data)); err != nil {
+		t.Fatalf("GeneratePieces: %v", err)
+	}
+
+	if len(tf.Pieces) != 3 {
+		t.Fatalf("expected 3 pieces for 25 bytes at piece length 10, got %d", len(tf.Pieces))
+	}
+
+	want := sha1.Sum(data[20:25])
+	if tf.Pieces[2] != want {
+		t.Errorf("final short piece hash = %x, want %x", tf.Pieces[2], want)
+	}
+}
+
+func TestGeneratePiecesExactMultiple(t *testing.T) {
+	data := bytes.Repeat([]byte("b"), 20)
+
+	tf := TorrentFile{PieceLength: 10}
+	if err := tf.GeneratePieces(bytes.NewReader(data)); err != nil {
+		t.Fatalf("GeneratePieces: %v", err)
+	}
+
+	if len(tf.Pieces) != 2 {
+		t.Fatalf("expected 2 pieces for 20 bytes at piece length 10, got %d", len(tf.Pieces))
+	}
+}
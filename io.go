@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) 2017 Daniel MÃ¼ller
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package torrentfile
+
+import (
+	"io"
+	"os"
+)
+
+// LoadFromFile opens path and decodes it as a .torrent file.
+func LoadFromFile(path string) (TorrentFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return TorrentFile{}, err
+	}
+	defer f.Close()
+
+	return DecodeTorrentFile(f)
+}
+
+// WriteTo writes t's bencoded metainfo dictionary to w. github.com/fuchsi/bencode
+// only encodes to a fully materialized []byte, so this can't avoid that
+// allocation, but it saves callers of SaveToFile a second copy of it.
+func (t TorrentFile) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(t.Encode())
+	return int64(n), err
+}
+
+// SaveToFile writes t's bencoded metainfo dictionary to the file at path,
+// creating or truncating it.
+func (t TorrentFile) SaveToFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = t.WriteTo(f)
+	return err
+}
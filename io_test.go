@@ -0,0 +1,63 @@
+package torrentfile
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLoadFileRoundTrip(t *testing.T) {
+	tf := TorrentFile{
+		Name:        "file.txt",
+		AnnounceUrl: "http://tracker.example/announce",
+		PieceLength: 16384,
+		Files:       []File{{Length: 0, Path: "file.txt"}},
+	}
+
+	path := filepath.Join(t.TempDir(), "test.torrent")
+	if err := tf.SaveToFile(path); err != nil {
+		t.Fatalf("SaveToFile: %v", err)
+	}
+
+	loaded, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+
+	if loaded.InfoHash() != tf.InfoHash() {
+		t.Errorf("InfoHash round-trip = %x, want %x", loaded.InfoHash(), tf.InfoHash())
+	}
+	if loaded.Name != tf.Name {
+		t.Errorf("Name round-trip = %q, want %q", loaded.Name, tf.Name)
+	}
+}
+
+func TestLoadFromFileMissing(t *testing.T) {
+	_, err := LoadFromFile(filepath.Join(t.TempDir(), "does-not-exist.torrent"))
+	if err == nil {
+		t.Fatal("LoadFromFile with missing file: expected error, got nil")
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	tf := TorrentFile{
+		Name:        "file.txt",
+		AnnounceUrl: "http://tracker.example/announce",
+		PieceLength: 16384,
+		Files:       []File{{Length: 0, Path: "file.txt"}},
+	}
+
+	var buf bytes.Buffer
+	n, err := tf.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	encoded := tf.Encode()
+	if n != int64(len(encoded)) {
+		t.Errorf("WriteTo n = %d, want %d", n, len(encoded))
+	}
+	if !bytes.Equal(buf.Bytes(), encoded) {
+		t.Error("WriteTo wrote bytes that differ from Encode()")
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2017 Daniel MÃ¼ller
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package torrentfile
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const magnetBtihPrefix = "urn:btih:"
+
+// Magnet renders t as a BEP 9 magnet URI, using the info hash as the
+// exact topic, the torrent name as the display name, and AnnounceUrl plus
+// every tracker in AnnounceList as tr parameters.
+func (t TorrentFile) Magnet() string {
+	hash := t.InfoHash()
+	u := "magnet:?xt=" + magnetBtihPrefix + hex.EncodeToString(hash[:])
+
+	if t.Name != "" {
+		u += "&dn=" + url.QueryEscape(t.Name)
+	}
+
+	trackers := make([]string, 0, len(t.AnnounceList)+1)
+	if t.AnnounceUrl != "" {
+		trackers = append(trackers, t.AnnounceUrl)
+	}
+	for _, tr := range t.ShuffledTrackers() {
+		if tr != t.AnnounceUrl {
+			trackers = append(trackers, tr)
+		}
+	}
+
+	for _, tr := range trackers {
+		u += "&tr=" + url.QueryEscape(tr)
+	}
+
+	return u
+}
+
+// ParseMagnet parses a BEP 9 magnet URI into a minimally populated
+// TorrentFile: Name, AnnounceUrl, AnnounceList and an info hash usable via
+// InfoHash. There is no info dict behind the returned TorrentFile, so it
+// is only a skeleton to bootstrap metadata fetch from, not a complete
+// torrent.
+func ParseMagnet(uri string) (TorrentFile, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return TorrentFile{}, err
+	}
+	if u.Scheme != "magnet" {
+		return TorrentFile{}, fmt.Errorf("torrentfile: not a magnet uri: %s", uri)
+	}
+
+	q := u.Query()
+
+	xt := q.Get("xt")
+	if !strings.HasPrefix(xt, magnetBtihPrefix) {
+		return TorrentFile{}, fmt.Errorf("torrentfile: missing or unsupported xt parameter: %s", xt)
+	}
+
+	hashBytes, err := hex.DecodeString(strings.TrimPrefix(xt, magnetBtihPrefix))
+	if err != nil {
+		return TorrentFile{}, err
+	}
+	if len(hashBytes) != PIECE_SIZE {
+		return TorrentFile{}, fmt.Errorf("torrentfile: invalid info hash length: %d", len(hashBytes))
+	}
+
+	t := TorrentFile{
+		Name:     q.Get("dn"),
+		skeleton: true,
+	}
+	copy(t.infoHash[:], hashBytes)
+
+	if trackers := q["tr"]; len(trackers) > 0 {
+		t.AnnounceUrl = trackers[0]
+		t.AnnounceList = [][]string{trackers}
+	}
+
+	return t, nil
+}
@@ -0,0 +1,28 @@
+package torrentfile
+
+import "testing"
+
+func TestMagnetRoundTrip(t *testing.T) {
+	tf := TorrentFile{
+		Name:        "file.txt",
+		AnnounceUrl: "http://tracker.example/announce",
+		PieceLength: 16384,
+		Files:       []File{{Length: 0, Path: "file.txt"}},
+	}
+	want := tf.InfoHash()
+
+	parsed, err := ParseMagnet(tf.Magnet())
+	if err != nil {
+		t.Fatalf("ParseMagnet: %v", err)
+	}
+
+	if parsed.InfoHash() != want {
+		t.Errorf("InfoHash round-trip = %x, want %x", parsed.InfoHash(), want)
+	}
+	if parsed.Name != tf.Name {
+		t.Errorf("Name round-trip = %q, want %q", parsed.Name, tf.Name)
+	}
+	if parsed.AnnounceUrl != tf.AnnounceUrl {
+		t.Errorf("AnnounceUrl round-trip = %q, want %q", parsed.AnnounceUrl, tf.AnnounceUrl)
+	}
+}
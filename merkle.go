@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2017 Daniel MÃ¼ller
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package torrentfile
+
+import (
+	"crypto/sha1"
+	"io"
+)
+
+// GenerateMerkleRoot hashes r into pieces exactly like GeneratePieces, then
+// builds the BEP 30 Merkle tree over those piece hashes and stores the
+// result in t.MerkleRoot. t.Pieces is left populated with the individual
+// piece hashes for local use (e.g. VerifyMerkleBranch); Encode writes them
+// to the info dict's pieces key alongside the root hash.
+func (t *TorrentFile) GenerateMerkleRoot(r io.Reader) error {
+	if err := t.GeneratePieces(r); err != nil {
+		return err
+	}
+
+	t.MerkleRoot = merkleRoot(t.Pieces)
+
+	return nil
+}
+
+// merkleRoot computes the BEP 30 Merkle tree root over pieces: the piece
+// hash list is padded with zero digests to the next power of two, then
+// adjacent pairs are hashed together, level by level, until one digest
+// remains.
+func merkleRoot(pieces [][PIECE_SIZE]byte) [PIECE_SIZE]byte {
+	if len(pieces) == 0 {
+		return [PIECE_SIZE]byte{}
+	}
+
+	n := 1
+	for n < len(pieces) {
+		n <<= 1
+	}
+
+	level := make([][PIECE_SIZE]byte, n)
+	copy(level, pieces)
+
+	for len(level) > 1 {
+		next := make([][PIECE_SIZE]byte, len(level)/2)
+		for i := range next {
+			next[i] = hashPair(level[2*i], level[2*i+1])
+		}
+		level = next
+	}
+
+	return level[0]
+}
+
+// VerifyMerkleBranch reports whether pieceHash at pieceIndex, combined
+// with branch (the sibling hashes from its leaf up to the root, in
+// bottom-up order), hashes to t.MerkleRoot.
+func (t TorrentFile) VerifyMerkleBranch(pieceIndex int, pieceHash [PIECE_SIZE]byte, branch [][PIECE_SIZE]byte) bool {
+	cur := pieceHash
+	idx := pieceIndex
+
+	for _, sibling := range branch {
+		if idx&1 == 0 {
+			cur = hashPair(cur, sibling)
+		} else {
+			cur = hashPair(sibling, cur)
+		}
+		idx >>= 1
+	}
+
+	return cur == t.MerkleRoot
+}
+
+func hashPair(left, right [PIECE_SIZE]byte) [PIECE_SIZE]byte {
+	var buf [PIECE_SIZE * 2]byte
+	copy(buf[:PIECE_SIZE], left[:])
+	copy(buf[PIECE_SIZE:], right[:])
+
+	return sha1.Sum(buf[:])
+}
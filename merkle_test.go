@@ -0,0 +1,56 @@
+package torrentfile
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMerkleRootRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("m"), 25)
+
+	tf := TorrentFile{
+		Name:        "file.txt",
+		AnnounceUrl: "http://tracker.example/announce",
+		PieceLength: 10,
+		Files:       []File{{Length: 25, Path: "file.txt"}},
+	}
+	if err := tf.GenerateMerkleRoot(bytes.NewReader(data)); err != nil {
+		t.Fatalf("GenerateMerkleRoot: %v", err)
+	}
+
+	decoded, err := DecodeTorrentFile(bytes.NewReader(tf.Encode()))
+	if err != nil {
+		t.Fatalf("DecodeTorrentFile: %v", err)
+	}
+
+	if decoded.MerkleRoot != tf.MerkleRoot {
+		t.Fatalf("MerkleRoot round-trip = %x, want %x", decoded.MerkleRoot, tf.MerkleRoot)
+	}
+}
+
+func TestVerifyMerkleBranch(t *testing.T) {
+	data := bytes.Repeat([]byte("m"), 25)
+
+	tf := TorrentFile{PieceLength: 10}
+	if err := tf.GenerateMerkleRoot(bytes.NewReader(data)); err != nil {
+		t.Fatalf("GenerateMerkleRoot: %v", err)
+	}
+
+	// 3 pieces pad to 4 leaves: [p0, p1, p2, zero].
+	p0, p1, p2 := tf.Pieces[0], tf.Pieces[1], tf.Pieces[2]
+	var zero [PIECE_SIZE]byte
+
+	left, right := hashPair(p0, p1), hashPair(p2, zero)
+
+	if !tf.VerifyMerkleBranch(0, p0, [][PIECE_SIZE]byte{p1, right}) {
+		t.Error("VerifyMerkleBranch(0) = false, want true")
+	}
+	if !tf.VerifyMerkleBranch(2, p2, [][PIECE_SIZE]byte{zero, left}) {
+		t.Error("VerifyMerkleBranch(2) = false, want true")
+	}
+
+	var wrongHash [PIECE_SIZE]byte
+	if tf.VerifyMerkleBranch(0, wrongHash, [][PIECE_SIZE]byte{p1, right}) {
+		t.Error("VerifyMerkleBranch with wrong piece hash = true, want false")
+	}
+}
@@ -0,0 +1,209 @@
+/*
+ * Copyright (c) 2017 Daniel MÃ¼ller
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package torrentfile
+
+import (
+	"crypto/sha1"
+	"errors"
+	"strings"
+
+	"github.com/fuchsi/bencode"
+)
+
+// Info is a typed view over the BEP 3 info dictionary. github.com/fuchsi/bencode
+// only (de)serializes map[string]interface{}, so Info is converted to and
+// from that shape by infoToDict/infoFromDict rather than by struct-tag
+// reflection.
+type Info struct {
+	PieceLength uint64
+	Pieces      []byte
+	Name        string
+	Length      uint64
+	Files       []InfoFile
+	Private     *bool
+	Source      string
+	// RootHash is the BEP 30 Merkle tree root hash. When present, Pieces
+	// may be empty: the per-piece hash list isn't carried in the
+	// .torrent at all, only exchanged and verified against RootHash out
+	// of band.
+	RootHash []byte
+}
+
+// InfoFile is one entry of Info.Files, the BEP 3 multi-file mode file
+// list. Path is the list of path segments bencode itself uses, e.g.
+// ["dir", "sub", "file.txt"]; File.Path joins/splits that on "/" at the
+// TorrentFile boundary.
+type InfoFile struct {
+	Length uint64
+	Path   []string
+}
+
+// MetaInfo is the BEP 3 top-level metainfo dictionary.
+//
+// InfoDict holds the info dictionary exactly as decoded (or as built for
+// encoding), as the raw map[string]interface{} github.com/fuchsi/bencode
+// works with, rather than as a typed Info. That way round-tripping a
+// torrent through Decode/Encode doesn't disturb info-dict keys this
+// package doesn't know about, as is common on private trackers.
+//
+// InfoBytes, when set, is the literal bencoded info dictionary as it
+// appeared in the decoded document. InfoHash hashes InfoBytes when
+// present instead of re-encoding InfoDict, since github.com/fuchsi/bencode's
+// map-based encoder canonicalizes (e.g. sorts keys), and a torrent whose
+// info dict wasn't already in canonical order would otherwise hash to a
+// different info hash than every other client computes for the same
+// file.
+type MetaInfo struct {
+	InfoDict     map[string]interface{}
+	InfoBytes    []byte
+	Announce     string
+	AnnounceList [][]string
+	CreationDate int64
+	CreatedBy    string
+	Comment      string
+	Encoding     string
+}
+
+// InfoHash returns the SHA-1 digest of the info dictionary: of InfoBytes
+// verbatim when set, or of InfoDict re-encoded otherwise (e.g. for a
+// MetaInfo built from fields rather than decoded from a document).
+func (mi MetaInfo) InfoHash() [PIECE_SIZE]byte {
+	if len(mi.InfoBytes) > 0 {
+		return sha1.Sum(mi.InfoBytes)
+	}
+
+	return sha1.Sum(bencode.Encode(mi.InfoDict))
+}
+
+// Info decodes and returns the typed info dictionary carried in InfoDict.
+func (mi MetaInfo) Info() (Info, error) {
+	return infoFromDict(mi.InfoDict)
+}
+
+// infoToDict builds the map[string]interface{} form of info for
+// bencode.Encode, the same way TorrentFile.Encode built it by hand before
+// Info existed.
+func infoToDict(info Info) map[string]interface{} {
+	dict := make(map[string]interface{})
+
+	dict["piece length"] = info.PieceLength
+	dict["pieces"] = string(info.Pieces)
+	if info.Private != nil && *info.Private {
+		dict["private"] = 1
+	}
+	if info.Name != "" {
+		dict["name"] = info.Name
+	}
+	if info.Source != "" {
+		dict["source"] = info.Source
+	}
+	if len(info.RootHash) > 0 {
+		dict["root hash"] = string(info.RootHash)
+	}
+
+	if info.Files != nil {
+		files := make([]interface{}, len(info.Files))
+		for i, f := range info.Files {
+			path := make([]interface{}, len(f.Path))
+			for j, p := range f.Path {
+				path[j] = p
+			}
+
+			files[i] = map[string]interface{}{
+				"length": f.Length,
+				"path":   path,
+			}
+		}
+		dict["files"] = files
+	} else {
+		dict["length"] = info.Length
+	}
+
+	return dict
+}
+
+// infoFromDict converts the map[string]interface{} bencode.Decode hands
+// back for an info dictionary into an Info.
+func infoFromDict(dict map[string]interface{}) (Info, error) {
+	if dict == nil {
+		return Info{}, errors.New("torrentfile: missing info dict")
+	}
+
+	pieceLength, ok := dict["piece length"].(int64)
+	if !ok {
+		return Info{}, errors.New("torrentfile: info dict missing piece length")
+	}
+
+	info := Info{PieceLength: uint64(pieceLength)}
+
+	if rootHash, ok := dict["root hash"]; ok {
+		info.RootHash = []byte(rootHash.(string))
+	}
+
+	if pieces, ok := dict["pieces"].(string); ok {
+		info.Pieces = []byte(pieces)
+	} else if len(info.RootHash) == 0 {
+		// BEP 30 torrents may omit the piece hash list in favor of root
+		// hash; everyone else must carry it.
+		return Info{}, errors.New("torrentfile: info dict missing pieces")
+	}
+
+	if name, ok := dict["name"]; ok {
+		info.Name = name.(string)
+	}
+	if source, ok := dict["source"]; ok {
+		info.Source = source.(string)
+	}
+	if private, ok := dict["private"]; ok {
+		v := private.(int64) == 1
+		info.Private = &v
+	}
+
+	if filesRaw, ok := dict["files"]; ok {
+		files := filesRaw.([]interface{})
+		info.Files = make([]InfoFile, len(files))
+		for i, v := range files {
+			file := v.(map[string]interface{})
+			pathRaw := file["path"].([]interface{})
+			path := make([]string, len(pathRaw))
+			for j, p := range pathRaw {
+				path[j] = p.(string)
+			}
+			info.Files[i] = InfoFile{Length: uint64(file["length"].(int64)), Path: path}
+		}
+	} else if length, ok := dict["length"]; ok {
+		info.Length = uint64(length.(int64))
+	}
+
+	return info, nil
+}
+
+// joinPath and splitPath convert between the bencode path-segment list
+// and the "/"-joined File.Path this package exposes publicly.
+func joinPath(path []string) string {
+	return strings.Join(path, "/")
+}
+
+func splitPath(path string) []string {
+	return strings.Split(path, "/")
+}
@@ -0,0 +1,69 @@
+package torrentfile
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"testing"
+)
+
+func TestInfoHashIgnoresNonCanonicalKeyOrder(t *testing.T) {
+	// bencode dicts are canonically sorted by key, but a decoder must
+	// still accept a dict whose keys appear out of order. "pieces" sorts
+	// after "piece length" canonically, so this info dict is valid but
+	// not canonically ordered.
+	info := "d6:pieces1:x12:piece lengthi10ee"
+	doc := "d8:announce0:4:info" + info + "e"
+
+	tf, err := DecodeTorrentFile(bytes.NewReader([]byte(doc)))
+	if err != nil {
+		t.Fatalf("DecodeTorrentFile: %v", err)
+	}
+
+	want := sha1.Sum([]byte(info))
+	if got := tf.InfoHash(); got != want {
+		t.Errorf("InfoHash = %x, want %x (literal info-dict bytes)", got, want)
+	}
+}
+
+func TestDecodeTorrentFileMissingInfoDict(t *testing.T) {
+	_, err := DecodeTorrentFile(bytes.NewReader([]byte("d8:announce0:e")))
+	if err == nil {
+		t.Fatal("DecodeTorrentFile with no info dict: expected error, got nil")
+	}
+}
+
+func TestInfoFromDictMissingPieceLength(t *testing.T) {
+	_, err := infoFromDict(map[string]interface{}{"pieces": "x"})
+	if err == nil {
+		t.Fatal("infoFromDict with no piece length: expected error, got nil")
+	}
+}
+
+func TestInfoRoundTripMultiFile(t *testing.T) {
+	tf := TorrentFile{
+		Name:        "root",
+		AnnounceUrl: "http://tracker.example/announce",
+		PieceLength: 16384,
+		Files: []File{
+			{Length: 10, Path: "a.txt"},
+			{Length: 20, Path: "dir/b.txt"},
+		},
+	}
+
+	decoded, err := DecodeTorrentFile(bytes.NewReader(tf.Encode()))
+	if err != nil {
+		t.Fatalf("DecodeTorrentFile: %v", err)
+	}
+
+	info, err := decoded.mi.Info()
+	if err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+
+	if len(info.Files) != 2 || info.Files[1].Path[1] != "b.txt" {
+		t.Fatalf("Info.Files round-trip = %#v", info.Files)
+	}
+	if decoded.InfoHash() != tf.InfoHash() {
+		t.Errorf("InfoHash round-trip = %x, want %x", decoded.InfoHash(), tf.InfoHash())
+	}
+}
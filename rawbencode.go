@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) 2017 Daniel MÃ¼ller
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package torrentfile
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+// findInfoDictBytes scans the top-level bencoded dictionary in data and
+// returns the literal bytes of its "info" value, without decoding that
+// value at all. github.com/fuchsi/bencode's Decode only hands back a
+// map[string]interface{}, which loses the original byte layout (e.g. key
+// order) once re-encoded; this lets MetaInfo.InfoHash hash the exact bytes
+// that were in the file instead.
+func findInfoDictBytes(data []byte) ([]byte, error) {
+	if len(data) == 0 || data[0] != 'd' {
+		return nil, errors.New("torrentfile: not a bencoded dictionary")
+	}
+
+	pos := 1
+	for pos < len(data) && data[pos] != 'e' {
+		key, next, err := decodeRawString(data, pos)
+		if err != nil {
+			return nil, err
+		}
+
+		valueStart := next
+		valueEnd, err := skipRawValue(data, valueStart)
+		if err != nil {
+			return nil, err
+		}
+
+		if key == "info" {
+			return data[valueStart:valueEnd], nil
+		}
+
+		pos = valueEnd
+	}
+
+	return nil, errors.New("torrentfile: missing info dict")
+}
+
+// skipRawValue returns the offset just past the single bencode value
+// starting at data[pos], without otherwise interpreting it.
+func skipRawValue(data []byte, pos int) (int, error) {
+	if pos >= len(data) {
+		return 0, errors.New("torrentfile: unexpected end of bencode data")
+	}
+
+	switch {
+	case data[pos] == 'i':
+		end := bytes.IndexByte(data[pos:], 'e')
+		if end < 0 {
+			return 0, errors.New("torrentfile: unterminated bencode integer")
+		}
+		return pos + end + 1, nil
+
+	case data[pos] == 'l':
+		p := pos + 1
+		for p < len(data) && data[p] != 'e' {
+			next, err := skipRawValue(data, p)
+			if err != nil {
+				return 0, err
+			}
+			p = next
+		}
+		if p >= len(data) {
+			return 0, errors.New("torrentfile: unterminated bencode list")
+		}
+		return p + 1, nil
+
+	case data[pos] == 'd':
+		p := pos + 1
+		for p < len(data) && data[p] != 'e' {
+			_, next, err := decodeRawString(data, p) // dict keys are always strings
+			if err != nil {
+				return 0, err
+			}
+			next, err = skipRawValue(data, next)
+			if err != nil {
+				return 0, err
+			}
+			p = next
+		}
+		if p >= len(data) {
+			return 0, errors.New("torrentfile: unterminated bencode dictionary")
+		}
+		return p + 1, nil
+
+	case data[pos] >= '0' && data[pos] <= '9':
+		_, next, err := decodeRawString(data, pos)
+		return next, err
+
+	default:
+		return 0, errors.New("torrentfile: invalid bencode type")
+	}
+}
+
+// decodeRawString decodes the bencode string ("<length>:<bytes>") starting
+// at data[pos] and returns its value plus the offset just past it.
+func decodeRawString(data []byte, pos int) (string, int, error) {
+	if pos >= len(data) || data[pos] < '0' || data[pos] > '9' {
+		return "", 0, errors.New("torrentfile: expected bencode string")
+	}
+
+	colon := bytes.IndexByte(data[pos:], ':')
+	if colon < 0 {
+		return "", 0, errors.New("torrentfile: unterminated bencode string length")
+	}
+
+	n, err := strconv.Atoi(string(data[pos : pos+colon]))
+	if err != nil || n < 0 {
+		return "", 0, errors.New("torrentfile: invalid bencode string length")
+	}
+
+	start := pos + colon + 1
+	end := start + n
+	if end > len(data) {
+		return "", 0, errors.New("torrentfile: bencode string runs past end of data")
+	}
+
+	return string(data[start:end]), end, nil
+}
@@ -23,8 +23,11 @@
 package torrentfile
 
 import (
-	"crypto/sha1"
+	"bufio"
+	"bytes"
+	"errors"
 	"io"
+	"math/rand"
 	"strings"
 	"time"
 
@@ -33,10 +36,16 @@ import (
 
 const PIECE_SIZE = 20
 
+// TorrentFile is a convenient, flattened view over a MetaInfo/Info pair.
+// It is kept around, field-for-field compatible with earlier versions of
+// this package, as a façade: Encode/DecodeTorrentFile translate to and
+// from the MetaInfo/Info types so callers who want the raw bencode
+// representation (e.g. to preserve private-tracker specific info-dict
+// keys) can use MetaInfo directly instead.
 type TorrentFile struct {
 	Name         string
 	AnnounceUrl  string
-	AnnounceList []string
+	AnnounceList [][]string
 	PieceLength  uint64
 	Pieces       [][PIECE_SIZE]byte
 	Files        []File
@@ -45,8 +54,20 @@ type TorrentFile struct {
 	CreatedBy    string
 	CreationDate time.Time
 	Encoding     string
-
-	info map[string]interface{}
+	// MerkleRoot is the BEP 30 Merkle tree root hash over the piece
+	// hashes. It is the zero value for torrents using a plain BEP 3
+	// piece hash list.
+	MerkleRoot [PIECE_SIZE]byte
+
+	// mi caches the MetaInfo this TorrentFile was decoded from, InfoDict
+	// included, so Encode and InfoHash can reuse the original info dict
+	// verbatim instead of re-deriving it from the fields above.
+	mi MetaInfo
+	// skeleton marks a TorrentFile that was never backed by a real info
+	// dict (e.g. one parsed from a magnet link), in which case infoHash
+	// is the only source of truth for InfoHash.
+	skeleton bool
+	infoHash [PIECE_SIZE]byte
 }
 
 type File struct {
@@ -64,175 +85,244 @@ func (t TorrentFile) TotalSize() uint64 {
 	return totalSize
 }
 
+// InfoHash returns the SHA-1 info hash. For a TorrentFile decoded from a
+// document, this hashes the literal info-dict bytes from that document
+// (see MetaInfo.InfoBytes), so the info hash matches what every other
+// client computes for the same file regardless of the original key
+// order; for a TorrentFile built from fields, it is derived by
+// re-encoding the info dictionary, which canonicalizes key order.
 func (t TorrentFile) InfoHash() [PIECE_SIZE]byte {
-	infoStr := bencode.Encode(t.info)
-	return sha1.Sum(infoStr)
+	if t.skeleton {
+		return t.infoHash
+	}
+
+	return t.toMetaInfo().InfoHash()
 }
 
-func (t TorrentFile) Encode() []byte {
-	dict := make(map[string]interface{})
-	info := make(map[string]interface{})
+// ShuffledTrackers flattens AnnounceList into a single slice in BEP 12 tier
+// order, shuffling the trackers within each tier before appending them so
+// repeated calls distribute load across a tier's trackers.
+func (t TorrentFile) ShuffledTrackers() []string {
+	trackers := make([]string, 0, len(t.AnnounceList))
 
-	// global dict
-	dict["announce"] = t.AnnounceUrl
-	if len(t.AnnounceList) > 0 {
-		dict["announce-list"] = t.AnnounceList
-	}
-	if t.CreationDate.Unix() > 0 {
-		dict["creation date"] = t.CreationDate.Unix()
+	for _, tier := range t.AnnounceList {
+		shuffled := make([]string, len(tier))
+		copy(shuffled, tier)
+		rand.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+
+		trackers = append(trackers, shuffled...)
 	}
-	if t.CreatedBy != "" {
-		dict["created by"] = t.CreatedBy
+
+	return trackers
+}
+
+// toInfo builds the Info dictionary for t's current fields. It is only
+// used when t isn't already backed by a decoded MetaInfo (see
+// toMetaInfo), since a decoded MetaInfo's InfoDict must be preserved
+// verbatim to keep unknown keys intact.
+func (t TorrentFile) toInfo() Info {
+	info := Info{
+		PieceLength: t.PieceLength,
+		Pieces:      flattenPieces(t.Pieces),
+		Name:        t.Name,
 	}
-	if t.Comment != "" {
-		dict["comment"] = t.Comment
+
+	if t.Private {
+		private := true
+		info.Private = &private
 	}
-	if t.Encoding != "" {
-		dict["encoding"] = t.Encoding
+
+	var zeroRoot [PIECE_SIZE]byte
+	if t.MerkleRoot != zeroRoot {
+		info.RootHash = append([]byte(nil), t.MerkleRoot[:]...)
 	}
 
-	// info dict
-	info["piece length"] = t.PieceLength
-	var pieces string
-	for _, v := range t.Pieces {
-		pieces += string(v[:])
+	if len(t.Files) == 1 && strings.Count(t.Files[0].Path, "/") == 0 { // single file mode
+		info.Name = t.Files[0].Path
+		info.Length = t.Files[0].Length
+	} else {
+		info.Files = make([]InfoFile, len(t.Files))
+		for i, f := range t.Files {
+			info.Files[i] = InfoFile{Length: f.Length, Path: splitPath(f.Path)}
+		}
 	}
-	info["pieces"] = pieces
-	if t.Private {
-		info["private"] = 1
+
+	return info
+}
+
+// toMetaInfo assembles the MetaInfo for t, reusing the cached InfoDict
+// from a prior decode when available so round-tripping a torrent doesn't
+// touch the info dict's keys.
+func (t TorrentFile) toMetaInfo() MetaInfo {
+	mi := t.mi
+
+	mi.Announce = t.AnnounceUrl
+	mi.AnnounceList = t.AnnounceList
+	if !t.CreationDate.IsZero() {
+		mi.CreationDate = t.CreationDate.Unix()
 	}
-	if t.Name != "" {
-		info["name"] = t.Name
+	mi.CreatedBy = t.CreatedBy
+	mi.Comment = t.Comment
+	mi.Encoding = t.Encoding
+
+	if mi.InfoDict == nil {
+		mi.InfoDict = infoToDict(t.toInfo())
 	}
 
-	// files list
-	singleFile := false
-	if len(t.Files) == 1 { // single file mode
-		if strings.Count(t.Files[0].Path, "/") == 0 { // really single file mode
-			singleFile = true
-			info["name"] = t.Files[0].Path
-			info["length"] = t.Files[0].Length
+	return mi
+}
+
+// Encode renders t as a bencoded metainfo dictionary.
+func (t TorrentFile) Encode() []byte {
+	mi := t.toMetaInfo()
+
+	dict := make(map[string]interface{})
+	dict["announce"] = mi.Announce
+	if len(mi.AnnounceList) > 0 {
+		tiers := make([]interface{}, len(mi.AnnounceList))
+		for i, tier := range mi.AnnounceList {
+			trackers := make([]interface{}, len(tier))
+			for j, tracker := range tier {
+				trackers[j] = tracker
+			}
+			tiers[i] = trackers
 		}
+		dict["announce-list"] = tiers
 	}
-	if !singleFile {
-		files := make([]interface{}, len(t.Files))
-		for i, v := range t.Files {
-			file := make(map[string]interface{}, 2)
-			file["length"] = v.Length
-			file["path"] = partitionPath(v.Path)
-			files[i] = file
-		}
-		info["files"] = files
+	if mi.CreationDate != 0 {
+		dict["creation date"] = mi.CreationDate
 	}
-
-	dict["info"] = info
+	if mi.CreatedBy != "" {
+		dict["created by"] = mi.CreatedBy
+	}
+	if mi.Comment != "" {
+		dict["comment"] = mi.Comment
+	}
+	if mi.Encoding != "" {
+		dict["encoding"] = mi.Encoding
+	}
+	dict["info"] = mi.InfoDict
 
 	return bencode.Encode(dict)
 }
 
+// DecodeTorrentFile decodes a bencoded metainfo dictionary into a
+// TorrentFile. reader is wrapped in a bufio.Reader, so callers can pass a
+// raw *os.File or network connection without paying a syscall per byte.
 func DecodeTorrentFile(reader io.Reader) (TorrentFile, error) {
-	dict, err := bencode.Decode(reader)
+	data, err := io.ReadAll(bufio.NewReader(reader))
 	if err != nil {
 		return TorrentFile{}, err
 	}
 
-	info := dict["info"].(map[string]interface{})
+	dict, err := bencode.Decode(bytes.NewReader(data))
+	if err != nil {
+		return TorrentFile{}, err
+	}
 
-	torrentfile := TorrentFile{
-		AnnounceUrl: dict["announce"].(string),
-		PieceLength: uint64(info["piece length"].(int64)),
-		Pieces:      decodePieces(info["pieces"].(string)),
-		info:        info,
+	infoDict, ok := dict["info"].(map[string]interface{})
+	if !ok {
+		return TorrentFile{}, errors.New("torrentfile: missing info dict")
 	}
 
-	if info["name"] != nil {
-		torrentfile.Name = info["name"].(string)
+	infoBytes, err := findInfoDictBytes(data)
+	if err != nil {
+		return TorrentFile{}, err
 	}
-	if info["private"] != nil {
-		torrentfile.Private = info["private"].(int64) == 1
+
+	mi := MetaInfo{InfoDict: infoDict, InfoBytes: infoBytes}
+	if announce, ok := dict["announce"]; ok {
+		mi.Announce = announce.(string)
 	}
-	if dict["comment"] != nil {
-		torrentfile.Comment = dict["comment"].(string)
+	if comment, ok := dict["comment"]; ok {
+		mi.Comment = comment.(string)
 	}
-	if dict["created by"] != nil {
-		torrentfile.CreatedBy = dict["created by"].(string)
+	if createdBy, ok := dict["created by"]; ok {
+		mi.CreatedBy = createdBy.(string)
 	}
-	if dict["creation date"] != nil {
-		torrentfile.CreationDate = time.Unix(dict["creation date"].(int64), 0)
-	} else {
-		torrentfile.CreationDate = time.Unix(0, 0)
+	if creationDate, ok := dict["creation date"]; ok {
+		mi.CreationDate = creationDate.(int64)
 	}
-	if dict["encoding"] != nil {
-		torrentfile.Encoding = dict["encoding"].(string)
+	if encoding, ok := dict["encoding"]; ok {
+		mi.Encoding = encoding.(string)
 	}
+	if announceList, ok := dict["announce-list"]; ok {
+		tiersRaw := announceList.([]interface{})
+		tiers := make([][]string, 0, len(tiersRaw))
 
-	if info["files"] != nil { // multiple file mode
-		files := info["files"].([]interface{})
-		torrentfile.Files = decodeFiles(&files)
-	} else {
-		filename := ""
-		if info["name"] != nil {
-			filename = info["name"].(string)
-		}
-		torrentfile.Files = []File{}
-		torrentfile.Files = append(torrentfile.Files, File{Length: uint64(info["length"].(int64)), Path: filename})
-	}
+		for _, tierRaw := range tiersRaw {
+			trackersRaw := tierRaw.([]interface{})
+			trackers := make([]string, 0, len(trackersRaw))
 
-	if dict["announce-list"] != nil {
-		l := info["announce-list"].([]interface{})
-		al := make([]string, len(l))
+			for _, v := range trackersRaw {
+				trackers = append(trackers, v.(string))
+			}
 
-		for _, v := range l {
-			al = append(al, v.(string))
+			tiers = append(tiers, trackers)
 		}
 
-		torrentfile.AnnounceList = al
+		mi.AnnounceList = tiers
 	}
 
-	return torrentfile, nil
-}
-
-func decodeFiles(fileList *[]interface{}) []File {
-	files := make([]File, 0, len(*fileList))
+	info, err := infoFromDict(infoDict)
+	if err != nil {
+		return TorrentFile{}, err
+	}
 
-	for _, v := range *fileList {
-		file := v.(map[string]interface{})
-		files = append(files, File{Length: uint64(file["length"].(int64)), Path: flattenPath(file["path"].([]interface{}))})
+	torrentfile := TorrentFile{
+		AnnounceUrl:  mi.Announce,
+		AnnounceList: mi.AnnounceList,
+		PieceLength:  info.PieceLength,
+		Pieces:       decodePieces(info.Pieces),
+		Name:         info.Name,
+		Private:      info.Private != nil && *info.Private,
+		Comment:      mi.Comment,
+		CreatedBy:    mi.CreatedBy,
+		Encoding:     mi.Encoding,
+		mi:           mi,
 	}
 
-	return files
-}
+	if mi.CreationDate != 0 {
+		torrentfile.CreationDate = time.Unix(mi.CreationDate, 0)
+	} else {
+		torrentfile.CreationDate = time.Unix(0, 0)
+	}
 
-func flattenPath(pathList []interface{}) string {
-	var path string
+	if info.Files != nil { // multiple file mode
+		torrentfile.Files = make([]File, len(info.Files))
+		for i, f := range info.Files {
+			torrentfile.Files[i] = File{Length: f.Length, Path: joinPath(f.Path)}
+		}
+	} else {
+		torrentfile.Files = []File{{Length: info.Length, Path: info.Name}}
+	}
 
-	for _, p := range pathList {
-		path += p.(string) + "/"
+	if len(info.RootHash) == PIECE_SIZE {
+		copy(torrentfile.MerkleRoot[:], info.RootHash)
 	}
 
-	return strings.TrimRight(path, "/")
+	return torrentfile, nil
 }
 
-func partitionPath(path string) []interface{} {
-	p := make([]interface{}, strings.Count(path, "/")+1)
-	for i, v := range strings.Split(path, "/") {
-		p[i] = v
+// flattenPieces concatenates pieces into the raw bytes the info dict's
+// "pieces" key holds.
+func flattenPieces(pieces [][PIECE_SIZE]byte) []byte {
+	buf := make([]byte, 0, len(pieces)*PIECE_SIZE)
+	for _, p := range pieces {
+		buf = append(buf, p[:]...)
 	}
 
-	return p
+	return buf
 }
 
-func decodePieces(pieceString string) [][PIECE_SIZE]byte {
-	a := []byte(pieceString)
-	pieces := make([][PIECE_SIZE]byte, 0, len(a)/PIECE_SIZE/2)
-	var buf [PIECE_SIZE]byte
-
-	for i, b := range a {
-		buf[(i % PIECE_SIZE)] = b
-		if (i+1)%PIECE_SIZE == 0 {
-			pieces = append(pieces, buf)
-			buf = [PIECE_SIZE]byte{}
-		}
+// decodePieces splits the raw bytes of the info dict's "pieces" key back
+// into individual piece hashes.
+func decodePieces(data []byte) [][PIECE_SIZE]byte {
+	pieces := make([][PIECE_SIZE]byte, len(data)/PIECE_SIZE)
+	for i := range pieces {
+		copy(pieces[i][:], data[i*PIECE_SIZE:(i+1)*PIECE_SIZE])
 	}
 
 	return pieces
@@ -0,0 +1,29 @@
+package torrentfile
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestAnnounceListRoundTrip(t *testing.T) {
+	tf := TorrentFile{
+		Name:        "file.txt",
+		AnnounceUrl: "http://tracker.example/announce",
+		AnnounceList: [][]string{
+			{"http://tracker.example/announce"},
+			{"http://backup1.example/announce", "http://backup2.example/announce"},
+		},
+		PieceLength: 16384,
+		Files:       []File{{Length: 0, Path: "file.txt"}},
+	}
+
+	decoded, err := DecodeTorrentFile(bytes.NewReader(tf.Encode()))
+	if err != nil {
+		t.Fatalf("DecodeTorrentFile: %v", err)
+	}
+
+	if !reflect.DeepEqual(decoded.AnnounceList, tf.AnnounceList) {
+		t.Fatalf("AnnounceList round-trip = %#v, want %#v", decoded.AnnounceList, tf.AnnounceList)
+	}
+}